@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"git.sr.ht/~tristan957/monkey/pipeline"
+)
+
+func runCheck(args []string) error {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	workers := flags.Int("j", runtime.NumCPU(), "number of files to check in parallel")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: monkey check [flags] [file | dir/...]...\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+
+		return err
+	}
+
+	patterns := flags.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	return check(patterns, *workers, os.Stdout)
+}
+
+// check lexes the files named by patterns, writing any diagnostics to w.
+func check(patterns []string, workers int, w io.Writer) error {
+	files, err := pipeline.Files(patterns)
+	if err != nil {
+		return err
+	}
+
+	diagnostics := pipeline.Diagnostics(pipeline.LexFiles(files, workers))
+	problemFiles := make(map[string]bool)
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintln(w, diagnostic)
+		problemFiles[diagnostic.Path] = true
+	}
+
+	if len(diagnostics) > 0 {
+		return fmt.Errorf("found %d problem(s) in %d of %d file(s)", len(diagnostics), len(problemFiles), len(files))
+	}
+
+	return nil
+}
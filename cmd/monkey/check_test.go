@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "monkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sources := map[string]string{
+		"a.mk":     "let a = $;",
+		"b.mk":     "let b = 1;",
+		"sub/c.mk": "let c = ?;\nlet d = \"x",
+	}
+	for path, contents := range sources {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	err = check([]string{dir + "/..."}, 4, &out)
+	if err == nil {
+		t.Fatal("expected an error when problems are found")
+	}
+
+	expectedErr := "found 3 problem(s) in 2 of 3 file(s)"
+	if err.Error() != expectedErr {
+		t.Fatalf("wrong error, expected=%q, actual=%q", expectedErr, err.Error())
+	}
+
+	a := filepath.Join(dir, "a.mk")
+	c := filepath.Join(dir, "sub", "c.mk")
+	expected := fmt.Sprintf(`%s (1, 9): unknown token "$"
+%s (1, 9): unknown token "?"
+%s: Unterminated string literal starting at %s (2, 9)
+`, a, c, c, c)
+	if out.String() != expected {
+		t.Fatalf("wrong output, expected=%q, actual=%q", expected, out.String())
+	}
+
+	out.Reset()
+	if err := check([]string{filepath.Join(dir, "b.mk")}, 4, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Len() != 0 {
+		t.Fatalf("expected no output, actual=%q", out.String())
+	}
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCommands_Help(t *testing.T) {
+	for _, cmd := range commands {
+		if err := cmd.run([]string{"-h"}); err != nil {
+			t.Fatalf("%s - expected -h to succeed, actual=%v", cmd.name, err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"git.sr.ht/~tristan957/monkey/lexer"
+	"git.sr.ht/~tristan957/monkey/token"
+)
+
+// tokenFormatter writes tokens in one of the output formats of the tokens
+// command.
+type tokenFormatter interface {
+	Format(tok token.Token) error
+	Flush() error
+}
+
+func runTokens(args []string) error {
+	flags := flag.NewFlagSet("tokens", flag.ContinueOnError)
+	format := flags.String("format", "table", "output format: table, json or compact")
+	trivia := flags.Bool("trivia", false, "include whitespace tokens")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: monkey tokens [flags] file...\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no files given")
+	}
+
+	return tokens(flags.Args(), *format, *trivia, os.Stdout)
+}
+
+// tokens writes the tokens of the files at paths to w in format. When lexing
+// fails, the tokens read up to the error are still written.
+func tokens(paths []string, format string, trivia bool, w io.Writer) error {
+	formatter, err := newTokenFormatter(format, w)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if err := dumpTokens(path, trivia, formatter); err != nil {
+			formatter.Flush()
+			return err
+		}
+	}
+
+	return formatter.Flush()
+}
+
+// dumpTokens lexes the file at path, handing each token to formatter.
+func dumpTokens(path string, trivia bool, formatter tokenFormatter) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	l := lexer.NewFromFile(file)
+	l.EmitTrivia(trivia)
+	if err := l.Initialize(); err != nil {
+		return err
+	}
+
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if err := formatter.Format(tok); err != nil {
+			return err
+		}
+
+		if tok.Type == token.EOF {
+			return nil
+		}
+	}
+}
+
+// newTokenFormatter returns the formatter for format writing to w.
+func newTokenFormatter(format string, w io.Writer) (tokenFormatter, error) {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "FILE\tSTART\tEND\tTYPE\tLITERAL")
+		return &tableFormatter{writer: tw}, nil
+	case "json":
+		return &jsonFormatter{encoder: json.NewEncoder(w)}, nil
+	case "compact":
+		return &compactFormatter{writer: w}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// tableFormatter writes tokens as aligned columns.
+type tableFormatter struct {
+	writer *tabwriter.Writer
+}
+
+func (f *tableFormatter) Format(tok token.Token) error {
+	_, err := fmt.Fprintf(f.writer, "%s\t%d:%d\t%d:%d\t%s\t%q\n", tok.Span.Start.File, tok.Span.Start.Line, tok.Span.Start.Column, tok.Span.End.Line, tok.Span.End.Column, tok.Type, tok.Literal)
+	return err
+}
+
+func (f *tableFormatter) Flush() error {
+	return f.writer.Flush()
+}
+
+// jsonPosition is the JSON representation of a token.Position.
+type jsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// jsonToken is the JSON representation of a token.Token.
+type jsonToken struct {
+	File    string       `json:"file,omitempty"`
+	Type    token.Type   `json:"type"`
+	Literal string       `json:"literal"`
+	Start   jsonPosition `json:"start"`
+	End     jsonPosition `json:"end"`
+}
+
+// jsonFormatter writes tokens as JSON lines.
+type jsonFormatter struct {
+	encoder *json.Encoder
+}
+
+func (f *jsonFormatter) Format(tok token.Token) error {
+	return f.encoder.Encode(jsonToken{
+		File:    tok.Span.Start.File,
+		Type:    tok.Type,
+		Literal: tok.Literal,
+		Start:   jsonPosition{Line: tok.Span.Start.Line, Column: tok.Span.Start.Column},
+		End:     jsonPosition{Line: tok.Span.End.Line, Column: tok.Span.End.Column},
+	})
+}
+
+func (f *jsonFormatter) Flush() error {
+	return nil
+}
+
+// compactFormatter writes tokens as `line:col-line:col TYPE "literal"`.
+type compactFormatter struct {
+	writer io.Writer
+}
+
+func (f *compactFormatter) Format(tok token.Token) error {
+	_, err := fmt.Fprintf(f.writer, "%d:%d-%d:%d %s %q\n", tok.Span.Start.Line, tok.Span.Start.Column, tok.Span.End.Line, tok.Span.End.Column, tok.Type, tok.Literal)
+	return err
+}
+
+func (f *compactFormatter) Flush() error {
+	return nil
+}
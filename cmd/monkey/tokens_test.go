@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// writeTempFile writes contents to a new temporary .mk file and returns its
+// name.
+func writeTempFile(t *testing.T, contents string) string {
+	file, err := ioutil.TempFile("", "*.mk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(contents); err != nil {
+		t.Fatal(err)
+	}
+
+	return file.Name()
+}
+
+// collapseSpaces replaces every run of spaces in s with a single space, so
+// table output can be compared without depending on column widths.
+func collapseSpaces(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func TestTokens(t *testing.T) {
+	valid := writeTempFile(t, "let x = 0b1_0;\n")
+	defer os.Remove(valid)
+	invalid := writeTempFile(t, "let a = 1;\nlet b = \"x")
+	defer os.Remove(invalid)
+
+	tests := []struct {
+		path      string
+		format    string
+		trivia    bool
+		expected  string
+		expectErr bool
+	}{
+		{valid, "compact", false, `1:1-1:3 LET "let"
+1:5-1:5 IDENTIFIER "x"
+1:7-1:7 = "="
+1:9-1:13 INTEGER "0b1_0"
+1:14-1:14 ; ";"
+2:1-2:1 EOF ""
+`, false},
+		{valid, "compact", true, `1:1-1:3 LET "let"
+1:4-1:4 WHITESPACE " "
+1:5-1:5 IDENTIFIER "x"
+1:6-1:6 WHITESPACE " "
+1:7-1:7 = "="
+1:8-1:8 WHITESPACE " "
+1:9-1:13 INTEGER "0b1_0"
+1:14-1:14 ; ";"
+1:15-1:15 WHITESPACE "\n"
+2:1-2:1 EOF ""
+`, false},
+		{valid, "json", false, `{"file":"` + valid + `","type":"LET","literal":"let","start":{"line":1,"column":1},"end":{"line":1,"column":3}}
+{"file":"` + valid + `","type":"IDENTIFIER","literal":"x","start":{"line":1,"column":5},"end":{"line":1,"column":5}}
+{"file":"` + valid + `","type":"=","literal":"=","start":{"line":1,"column":7},"end":{"line":1,"column":7}}
+{"file":"` + valid + `","type":"INTEGER","literal":"0b1_0","start":{"line":1,"column":9},"end":{"line":1,"column":13}}
+{"file":"` + valid + `","type":";","literal":";","start":{"line":1,"column":14},"end":{"line":1,"column":14}}
+{"file":"` + valid + `","type":"EOF","literal":"","start":{"line":2,"column":1},"end":{"line":2,"column":1}}
+`, false},
+		{valid, "table", false, `FILE START END TYPE LITERAL
+` + valid + ` 1:1 1:3 LET "let"
+` + valid + ` 1:5 1:5 IDENTIFIER "x"
+` + valid + ` 1:7 1:7 = "="
+` + valid + ` 1:9 1:13 INTEGER "0b1_0"
+` + valid + ` 1:14 1:14 ; ";"
+` + valid + ` 2:1 2:1 EOF ""
+`, false},
+		// The tokens before a lexer error are still written, even by the
+		// buffered table format.
+		{invalid, "table", false, `FILE START END TYPE LITERAL
+` + invalid + ` 1:1 1:3 LET "let"
+` + invalid + ` 1:5 1:5 IDENTIFIER "a"
+` + invalid + ` 1:7 1:7 = "="
+` + invalid + ` 1:9 1:9 INTEGER "1"
+` + invalid + ` 1:10 1:10 ; ";"
+` + invalid + ` 2:1 2:3 LET "let"
+` + invalid + ` 2:5 2:5 IDENTIFIER "b"
+` + invalid + ` 2:7 2:7 = "="
+`, true},
+		{invalid, "compact", false, `1:1-1:3 LET "let"
+1:5-1:5 IDENTIFIER "a"
+1:7-1:7 = "="
+1:9-1:9 INTEGER "1"
+1:10-1:10 ; ";"
+2:1-2:3 LET "let"
+2:5-2:5 IDENTIFIER "b"
+2:7-2:7 = "="
+`, true},
+	}
+
+	for i, tt := range tests {
+		var out bytes.Buffer
+		err := tokens([]string{tt.path}, tt.format, tt.trivia, &out)
+		if tt.expectErr && err == nil {
+			t.Fatalf("tests[%d] - expected an error", i)
+		} else if !tt.expectErr && err != nil {
+			t.Fatal(err)
+		}
+
+		actual := out.String()
+		if tt.format == "table" {
+			actual = collapseSpaces(actual)
+		}
+
+		if actual != tt.expected {
+			t.Fatalf("tests[%d] - wrong output, expected=%q, actual=%q", i, tt.expected, actual)
+		}
+	}
+}
+
+func TestNewTokenFormatter_UnknownFormat(t *testing.T) {
+	if _, err := newTokenFormatter("yaml", &bytes.Buffer{}); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,163 @@
+package lexer
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"git.sr.ht/~tristan957/monkey/token"
+)
+
+// Edit describes a change to a text buffer. The text from Start up to, but
+// not including, End is replaced with Text. Start and End are positions in the
+// buffer before the change; their File is ignored.
+type Edit struct {
+	Start token.Position
+	End   token.Position
+	Text  string
+}
+
+// Relex updates tokens, the result of lexing a buffer before edit was applied,
+// to match source, the buffer after edit was applied. Only the tokens around
+// the edit are lexed again; once the lexer produces a token starting at the
+// same place in the unchanged text as a previous token, the rest of the
+// previous tokens are reused with their positions shifted. tokens must end
+// with an EOF token and must not include trivia. The relexed tokens keep the
+// file of tokens.
+func Relex(source string, tokens []token.Token, edit Edit) ([]token.Token, error) {
+	// The last token ending before the edit may still be extended by it,
+	// like an identifier that is typed at, so lexing restarts from there.
+	restart := 0
+	for i, tok := range tokens {
+		if !isBefore(tok.Span.End, &edit.Start) {
+			break
+		}
+		restart = i
+	}
+
+	var file string
+	if len(tokens) > 0 {
+		file = tokens[0].Span.Start.File
+	}
+
+	start := token.Position{File: file, Line: 1, Column: 1}
+	if restart < len(tokens) && isBefore(tokens[restart].Span.Start, &edit.Start) {
+		start = *tokens[restart].Span.Start
+	} else {
+		restart = 0
+	}
+
+	// The first previous token after the edit is the earliest one that can be
+	// reused.
+	reuse := restart
+	for reuse < len(tokens) && isBefore(tokens[reuse].Span.Start, &edit.End) {
+		reuse++
+	}
+
+	editEnd := endOfInsertion(&edit)
+
+	l := newFromStringAt(source[offsetOf(source, &start):], start)
+	if err := l.Initialize(); err != nil {
+		return nil, err
+	}
+
+	result := append([]token.Token(nil), tokens[:restart]...)
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			return nil, err
+		}
+
+		if !isBefore(tok.Span.Start, editEnd) {
+			for reuse < len(tokens) && isBefore(shiftPosition(tokens[reuse].Span.Start, &edit, editEnd), tok.Span.Start) {
+				reuse++
+			}
+
+			if reuse < len(tokens) && isSame(shiftPosition(tokens[reuse].Span.Start, &edit, editEnd), tok.Span.Start) {
+				for _, old := range tokens[reuse:] {
+					result = append(result, shiftToken(old, &edit, editEnd))
+				}
+
+				return result, nil
+			}
+		}
+
+		result = append(result, tok)
+		if tok.Type == token.EOF {
+			return result, nil
+		}
+	}
+}
+
+// newFromStringAt creates a new Lexer for input, which begins at position in
+// a larger buffer.
+func newFromStringAt(input string, position token.Position) *Lexer {
+	l := NewFromString(input)
+	*l.currPosition = position
+	*l.nextPosition = position
+
+	return l
+}
+
+// isBefore returns whether p comes before other.
+func isBefore(p *token.Position, other *token.Position) bool {
+	return p.Line < other.Line || p.Line == other.Line && p.Column < other.Column
+}
+
+// isSame returns whether p and other are the same line and column. Unlike
+// Position.Equals, the file is not compared.
+func isSame(p *token.Position, other *token.Position) bool {
+	return p.Line == other.Line && p.Column == other.Column
+}
+
+// offsetOf returns the byte offset of position within source.
+func offsetOf(source string, position *token.Position) int {
+	line, column := 1, 1
+	for offset, ch := range source {
+		if line == position.Line && column == position.Column {
+			return offset
+		}
+
+		if ch == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+
+	return len(source)
+}
+
+// endOfInsertion returns the position just past the text inserted by edit, in
+// the buffer after the edit.
+func endOfInsertion(edit *Edit) *token.Position {
+	end := edit.Start.Copy()
+	if i := strings.LastIndexByte(edit.Text, '\n'); i >= 0 {
+		end.Line += strings.Count(edit.Text, "\n")
+		end.Column = 1 + utf8.RuneCountInString(edit.Text[i+1:])
+	} else {
+		end.Column += utf8.RuneCountInString(edit.Text)
+	}
+
+	return end
+}
+
+// shiftPosition moves position, which is at or after the end of edit in the
+// buffer before the edit, to where it is in the buffer after the edit.
+func shiftPosition(position *token.Position, edit *Edit, editEnd *token.Position) *token.Position {
+	shifted := position.Copy()
+	if position.Line == edit.End.Line {
+		shifted.Column += editEnd.Column - edit.End.Column
+	}
+	shifted.Line += editEnd.Line - edit.End.Line
+
+	return shifted
+}
+
+// shiftToken returns a copy of tok with its span shifted by edit.
+func shiftToken(tok token.Token, edit *Edit, editEnd *token.Position) token.Token {
+	tok.Span.Start = shiftPosition(tok.Span.Start, edit, editEnd)
+	tok.Span.End = shiftPosition(tok.Span.End, edit, editEnd)
+
+	return tok
+}
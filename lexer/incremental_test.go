@@ -0,0 +1,200 @@
+package lexer
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"git.sr.ht/~tristan957/monkey/token"
+)
+
+// fragments are the pieces random sources and edits are built from.
+var fragments = []string{
+	"let", "fn", "x", "_", "ab", "0", "12", "0x1f", "0b10", "1_000",
+	" ", " ", "\t", "\n", "\n\n",
+	"=", "+", "-", "*", "/", "%", "<", ">", "&", "|", "!", ":",
+	"(", ")", "{", "}", "[", "]", ";", ",",
+	`"str"`, `"`, "é",
+}
+
+func randomText(rng *rand.Rand, max int) string {
+	var builder strings.Builder
+	for n := rng.Intn(max + 1); n > 0; n-- {
+		builder.WriteString(fragments[rng.Intn(len(fragments))])
+	}
+
+	return builder.String()
+}
+
+// lexAll lexes input up to and including the EOF token, as if it were the
+// contents of file.
+func lexAll(input string, file string) ([]token.Token, error) {
+	l := newFromStringAt(input, token.Position{File: file, Line: 1, Column: 1})
+	if err := l.Initialize(); err != nil {
+		return nil, err
+	}
+
+	var tokens []token.Token
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			return tokens, nil
+		}
+	}
+}
+
+// positionAt returns the position of the byte offset within source.
+func positionAt(source string, offset int) token.Position {
+	position := token.Position{Line: 1, Column: 1}
+	for _, ch := range source[:offset] {
+		if ch == '\n' {
+			position.Line++
+			position.Column = 1
+		} else {
+			position.Column++
+		}
+	}
+
+	return position
+}
+
+// runeBoundary moves offset back to the start of the rune containing it.
+func runeBoundary(source string, offset int) int {
+	for offset > 0 && offset < len(source) && !isRuneStart(source[offset]) {
+		offset--
+	}
+
+	return offset
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xc0 != 0x80
+}
+
+func TestRelex_MatchesFullLex(t *testing.T) {
+	property := func(seed int64) bool {
+		rng := rand.New(rand.NewSource(seed))
+
+		// Edits from an editor don't name a file, even when the tokens do.
+		var file string
+		if rng.Intn(2) == 0 {
+			file = "f.mk"
+		}
+
+		before := randomText(rng, 30)
+		tokens, err := lexAll(before, file)
+		if err != nil {
+			// Only buffers that lexed are ever edited.
+			return true
+		}
+
+		start := runeBoundary(before, rng.Intn(len(before)+1))
+		end := runeBoundary(before, start+rng.Intn(len(before)-start+1))
+		edit := Edit{
+			Start: positionAt(before, start),
+			End:   positionAt(before, end),
+			Text:  randomText(rng, 3),
+		}
+		after := before[:start] + edit.Text + before[end:]
+
+		expected, expectedErr := lexAll(after, file)
+		actual, actualErr := Relex(after, tokens, edit)
+		if (expectedErr == nil) != (actualErr == nil) {
+			t.Logf("before=%q after=%q - error mismatch, expected=%v, actual=%v", before, after, expectedErr, actualErr)
+			return false
+		}
+
+		if len(expected) != len(actual) {
+			t.Logf("before=%q after=%q - token count wrong, expected=%d, actual=%d", before, after, len(expected), len(actual))
+			return false
+		}
+
+		for i := range expected {
+			if expected[i].Type != actual[i].Type || expected[i].Literal != actual[i].Literal || !expected[i].Span.Equals(&actual[i].Span) {
+				t.Logf("before=%q after=%q - tokens[%d] wrong, expected=%v, actual=%v", before, after, i, expected[i], actual[i])
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRelex_ShiftsFollowingTokens(t *testing.T) {
+	before := "let x = 1;\nlet y = x;\n"
+	tokens, err := lexAll(before, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the 1 with 0x10 followed by a new line.
+	edit := Edit{
+		Start: token.Position{Line: 1, Column: 9},
+		End:   token.Position{Line: 1, Column: 10},
+		Text:  "0x10\n",
+	}
+	after := "let x = 0x10\n;\nlet y = x;\n"
+
+	actual, err := Relex(after, tokens, edit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []expectedToken{
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 1, Column: 1}, End: &token.Position{Line: 1, Column: 3}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 1, Column: 5}, End: &token.Position{Line: 1, Column: 5}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 1, Column: 7}, End: &token.Position{Line: 1, Column: 7}}},
+		{token.INTEGER, "0x10", token.Span{Start: &token.Position{Line: 1, Column: 9}, End: &token.Position{Line: 1, Column: 12}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 2, Column: 1}, End: &token.Position{Line: 2, Column: 1}}},
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 3, Column: 1}, End: &token.Position{Line: 3, Column: 3}}},
+		{token.IDENTIFIER, "y", token.Span{Start: &token.Position{Line: 3, Column: 5}, End: &token.Position{Line: 3, Column: 5}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 3, Column: 7}, End: &token.Position{Line: 3, Column: 7}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 3, Column: 9}, End: &token.Position{Line: 3, Column: 9}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 3, Column: 10}, End: &token.Position{Line: 3, Column: 10}}},
+		{token.EOF, "", token.Span{Start: &token.Position{Line: 4, Column: 1}, End: &token.Position{Line: 4, Column: 1}}},
+	}
+
+	expectTokenList(t, actual, tests)
+
+	// The tokens before the edit are reused rather than lexed again.
+	if actual[0].Span.Start != tokens[0].Span.Start {
+		t.Fatal("expected the tokens before the edit to be reused")
+	}
+}
+
+func TestRelex_KeepsFile(t *testing.T) {
+	tokens, err := lexAll("abc def", "f.mk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Insert an x before the first token.
+	edit := Edit{
+		Start: token.Position{Line: 1, Column: 1},
+		End:   token.Position{Line: 1, Column: 1},
+		Text:  "x",
+	}
+
+	actual, err := Relex("xabc def", tokens, edit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []expectedToken{
+		{token.IDENTIFIER, "xabc", token.Span{Start: &token.Position{File: "f.mk", Line: 1, Column: 1}, End: &token.Position{File: "f.mk", Line: 1, Column: 4}}},
+		{token.IDENTIFIER, "def", token.Span{Start: &token.Position{File: "f.mk", Line: 1, Column: 6}, End: &token.Position{File: "f.mk", Line: 1, Column: 8}}},
+		{token.EOF, "", token.Span{Start: &token.Position{File: "f.mk", Line: 1, Column: 9}, End: &token.Position{File: "f.mk", Line: 1, Column: 9}}},
+	}
+
+	expectTokenList(t, actual, tests)
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -17,6 +18,7 @@ type Lexer struct {
 	currPosition *token.Position
 	nextPosition *token.Position
 	ch           rune
+	trivia       bool
 }
 
 // NewFromReader creates a new Lexer from an io.Reader implementation.
@@ -55,6 +57,34 @@ func NewFromString(input string) *Lexer {
 	return l
 }
 
+// NewFromReaderWithFile creates a new Lexer from an io.Reader implementation
+// holding the contents of the file called name. The positions of the produced
+// tokens carry name.
+func NewFromReaderWithFile(name string, input io.Reader) *Lexer {
+	reader := bufio.NewReader(input)
+	l := &Lexer{
+		input: reader,
+		currPosition: &token.Position{
+			File:   name,
+			Line:   1,
+			Column: 1,
+		},
+		nextPosition: &token.Position{
+			File:   name,
+			Line:   1,
+			Column: 1,
+		},
+	}
+
+	return l
+}
+
+// NewFromFile creates a new Lexer reading from file. The positions of the
+// produced tokens carry the name of the file.
+func NewFromFile(file *os.File) *Lexer {
+	return NewFromReaderWithFile(file.Name(), file)
+}
+
 // Initialize puts the Lexer into a fully working state and will return an
 // error if it cannot be initialized properly.
 func (l *Lexer) Initialize() error {
@@ -65,33 +95,56 @@ func (l *Lexer) Initialize() error {
 	return nil
 }
 
+// EmitTrivia sets whether NextToken returns the whitespace between tokens as
+// WHITESPACE tokens instead of skipping over it.
+func (l *Lexer) EmitTrivia(emit bool) {
+	l.trivia = emit
+}
+
 // NextToken returns the next token of the sequence
 func (l *Lexer) NextToken() (token.Token, error) {
 	var tok token.Token
+	var err error
 
-	if err := l.consumeWhitespace(); err != nil {
-		return tok, nil
+	if l.trivia && isWhitespace(l.ch) {
+		return l.readWhitespace()
+	}
+
+	if err = l.consumeWhitespace(); err != nil {
+		return tok, err
 	}
 
 	switch l.ch {
 	case '=':
 		tok = l.newToken(token.ASSIGN)
 	case '+':
-		tok = l.newToken(token.PLUS)
+		tok, err = l.newTokenIfNext(token.PLUS, twoCharacterToken{'=', token.PLUS_ASSIGN})
 	case '-':
-		tok = l.newToken(token.MINUS)
+		tok, err = l.newTokenIfNext(token.MINUS, twoCharacterToken{'=', token.MINUS_ASSIGN}, twoCharacterToken{'>', token.ARROW})
 	case '*':
-		tok = l.newToken(token.ASTERISK)
+		tok, err = l.newTokenIfNext(token.ASTERISK, twoCharacterToken{'=', token.ASTERISK_ASSIGN}, twoCharacterToken{'*', token.DOUBLE_ASTERISK})
 	case '/':
-		tok = l.newToken(token.FORWARD_SLASH)
+		tok, err = l.newTokenIfNext(token.FORWARD_SLASH, twoCharacterToken{'=', token.FORWARD_SLASH_ASSIGN})
+	case '%':
+		tok, err = l.newTokenIfNext(token.PERCENT, twoCharacterToken{'=', token.PERCENT_ASSIGN})
+	case '&':
+		tok, err = l.newTokenIfNext(token.AMPERSAND, twoCharacterToken{'&', token.AND})
+	case '|':
+		tok, err = l.newTokenIfNext(token.PIPE, twoCharacterToken{'|', token.OR})
+	case '^':
+		tok = l.newToken(token.CARET)
+	case '~':
+		tok = l.newToken(token.TILDE)
 	case '!':
 		tok = l.newToken(token.BANG)
 	case '<':
-		tok = l.newToken(token.LESS_THAN)
+		tok, err = l.newTokenIfNext(token.LESS_THAN, twoCharacterToken{'<', token.LEFT_SHIFT})
 	case '>':
-		tok = l.newToken(token.GREATER_THAN)
+		tok, err = l.newTokenIfNext(token.GREATER_THAN, twoCharacterToken{'>', token.RIGHT_SHIFT})
 	case ';':
 		tok = l.newToken(token.SEMICOLON)
+	case ':':
+		tok = l.newToken(token.COLON)
 	case '(':
 		tok = l.newToken(token.LEFT_PARENTHESES)
 	case ')':
@@ -102,6 +155,20 @@ func (l *Lexer) NextToken() (token.Token, error) {
 		tok = l.newToken(token.LEFT_BRACE)
 	case '}':
 		tok = l.newToken(token.RIGHT_BRACE)
+	case '"':
+		tok.Span.Start = l.currPosition.Copy()
+		str, err := l.readString()
+		if err != nil {
+			return tok, err
+		}
+
+		tok.Literal = str
+		tok.Type = token.STRING
+		tok.Span.End = l.currPosition.Copy()
+	case '[':
+		tok = l.newToken(token.LEFT_BRACKET)
+	case ']':
+		tok = l.newToken(token.RIGHT_BRACKET)
 	case 0:
 		currPositionCopy := l.currPosition.Copy()
 		tok.Literal = ""
@@ -129,11 +196,10 @@ func (l *Lexer) NextToken() (token.Token, error) {
 			var number string
 			if l.ch == '0' {
 				// peek the literal prefix
-				ch, err := l.input.Peek(1)
+				rn, err := l.peekChar()
 				if err != nil {
-					return tok, fmt.Errorf("Failed to peek character at line %d, column %d: %w", l.nextPosition.Line, l.nextPosition.Column, err)
+					return tok, err
 				}
-				rn, size := utf8.DecodeRune(ch)
 				switch rn {
 				case token.BINARY_PREFIX:
 					number, err = l.readBinaryInteger()
@@ -151,13 +217,17 @@ func (l *Lexer) NextToken() (token.Token, error) {
 						return tok, err
 					}
 				case utf8.RuneError:
-					if size == 1 {
-						return tok, fmt.Errorf("Invalid UTF-8 (empty character) at line %d, column %d", l.nextPosition.Line, l.nextPosition.Column)
-					} else if size == 0 {
-						return tok, fmt.Errorf("Invalid encoding, not UTF-8 at line %d, column %d", l.nextPosition.Line, l.nextPosition.Column)
-					}
+					return tok, fmt.Errorf("Invalid UTF-8 at %s", l.nextPosition)
 				default:
-					return tok, fmt.Errorf("Unrecognized integer prefix '%q'", rn)
+					// a lone 0 or a decimal literal with a leading 0
+					if isLetter(rn) && !isDigit(rn) {
+						return tok, fmt.Errorf("Unrecognized integer prefix %q at %s", rn, l.nextPosition)
+					}
+
+					number, err = l.readInteger()
+					if err != nil {
+						return tok, err
+					}
 				}
 			} else {
 				var err error
@@ -180,6 +250,10 @@ func (l *Lexer) NextToken() (token.Token, error) {
 		}
 	}
 
+	if err != nil {
+		return tok, err
+	}
+
 	if err := l.readChar(); err != nil {
 		return tok, err
 	}
@@ -193,6 +267,65 @@ func (l *Lexer) newToken(ttype token.Type) token.Token {
 	return token.Token{Type: ttype, Literal: string(l.ch), Span: token.Span{Start: currPositionCopy, End: currPositionCopy}}
 }
 
+// twoCharacterToken describes a token made up of the current character and
+// the one following it.
+type twoCharacterToken struct {
+	next  rune
+	ttype token.Type
+}
+
+// newTokenIfNext creates a two character token if the next character matches
+// one of the candidates, otherwise it creates a single character token of
+// type fallback.
+func (l *Lexer) newTokenIfNext(fallback token.Type, candidates ...twoCharacterToken) (token.Token, error) {
+	ch, err := l.peekChar()
+	if err != nil {
+		return token.Token{}, err
+	}
+
+	for _, candidate := range candidates {
+		if ch != candidate.next {
+			continue
+		}
+
+		start := l.currPosition.Copy()
+		first := l.ch
+		if err := l.readChar(); err != nil {
+			return token.Token{}, err
+		}
+
+		return token.Token{Type: candidate.ttype, Literal: string(first) + string(l.ch), Span: token.Span{Start: start, End: l.currPosition.Copy()}}, nil
+	}
+
+	return l.newToken(fallback), nil
+}
+
+// peekChar returns the next character of the input without consuming it. At
+// the end of the input, 0 is returned.
+func (l *Lexer) peekChar() (rune, error) {
+	ch, err := l.input.Peek(1)
+	if err != nil {
+		if err == io.EOF {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("Failed to peek character at %s: %w", l.nextPosition, err)
+	}
+
+	if ch[0] >= utf8.RuneSelf {
+		// The input may end before a full UTFMax bytes, in which case the
+		// decoding below reports what is left.
+		ch, err = l.input.Peek(utf8.UTFMax)
+		if err != nil && err != io.EOF {
+			return 0, fmt.Errorf("Failed to peek character at %s: %w", l.nextPosition, err)
+		}
+	}
+
+	rn, _ := utf8.DecodeRune(ch)
+
+	return rn, nil
+}
+
 // readChar reads a single character of the input
 func (l *Lexer) readChar() error {
 	_, err := l.input.Peek(1)
@@ -203,14 +336,14 @@ func (l *Lexer) readChar() error {
 			l.currPosition.Column = l.nextPosition.Column
 		} else {
 			l.ch = 0
-			return fmt.Errorf("Failed to peek character at line %d, column %d: %w", l.nextPosition.Line, l.nextPosition.Column, err)
+			return fmt.Errorf("Failed to peek character at %s: %w", l.nextPosition, err)
 		}
 	} else {
 		ch, _, err := l.input.ReadRune()
 		if err != nil {
-			return fmt.Errorf("Failed to read character at line %d, column %d: %w", l.nextPosition.Line, l.nextPosition.Column, err)
+			return fmt.Errorf("Failed to read character at %s: %w", l.nextPosition, err)
 		} else if ch == unicode.ReplacementChar {
-			return fmt.Errorf("Found invalid UTF-8 character at line %d, column %d", l.nextPosition.Line, l.nextPosition.Column)
+			return fmt.Errorf("Found invalid UTF-8 character at %s", l.nextPosition)
 		}
 
 		l.ch = ch
@@ -227,6 +360,11 @@ func (l *Lexer) readChar() error {
 	return nil
 }
 
+// isWhitespace checks if the input is a whitespace character.
+func isWhitespace(ch rune) bool {
+	return ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n'
+}
+
 // isLetter checks if the input is a letter.
 func isLetter(ch rune) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
@@ -270,7 +408,7 @@ func (l *Lexer) readInteger() (string, error) {
 	var builder strings.Builder
 	for isDigit(l.ch) {
 		if _, err := builder.WriteRune(l.ch); err != nil {
-			return "", fmt.Errorf("Unable to write integer literal (%q) at line %d, column %d", l.ch, l.currPosition.Line, l.currPosition.Column)
+			return "", fmt.Errorf("Unable to write integer literal (%q) at %s", l.ch, l.currPosition)
 		}
 		if err := l.readChar(); err != nil {
 			return "", err
@@ -286,13 +424,13 @@ func (l *Lexer) readBinaryInteger() (string, error) {
 
 	// write the 0
 	if _, err := builder.WriteRune(l.ch); err != nil {
-		return "", fmt.Errorf("Unable to write binary integer literal (%q) at line %d, column %d", l.ch, l.currPosition.Line, l.currPosition.Column)
+		return "", fmt.Errorf("Unable to write binary integer literal (%q) at %s", l.ch, l.currPosition)
 	}
 	if err := l.readChar(); err != nil {
 		return "", err
 	}
 	if _, err := builder.WriteRune(token.BINARY_PREFIX); err != nil {
-		return "", fmt.Errorf("Unable to write binary integer literal (%q) at line %d, column %d", token.BINARY_PREFIX, l.currPosition.Line, l.currPosition.Column)
+		return "", fmt.Errorf("Unable to write binary integer literal (%q) at %s", token.BINARY_PREFIX, l.currPosition)
 	}
 	if err := l.readChar(); err != nil {
 		return "", err
@@ -300,7 +438,7 @@ func (l *Lexer) readBinaryInteger() (string, error) {
 
 	for isBinaryDigit(l.ch) {
 		if _, err := builder.WriteRune(l.ch); err != nil {
-			return "", fmt.Errorf("Unable to write binary integer literal (%q) at line %d, column %d", l.ch, l.currPosition.Line, l.currPosition.Column)
+			return "", fmt.Errorf("Unable to write binary integer literal (%q) at %s", l.ch, l.currPosition)
 		}
 		if err := l.readChar(); err != nil {
 			return "", err
@@ -316,13 +454,13 @@ func (l *Lexer) readOctalInteger() (string, error) {
 
 	// write the 0
 	if _, err := builder.WriteRune(l.ch); err != nil {
-		return "", fmt.Errorf("Unable to write binary integer literal (%q) at line %d, column %d", l.ch, l.currPosition.Line, l.currPosition.Column)
+		return "", fmt.Errorf("Unable to write binary integer literal (%q) at %s", l.ch, l.currPosition)
 	}
 	if err := l.readChar(); err != nil {
 		return "", err
 	}
 	if _, err := builder.WriteRune(token.OCTAL_PREFIX); err != nil {
-		return "", fmt.Errorf("Unable to write octal integer literal (%q) at line %d, column %d", token.OCTAL_PREFIX, l.currPosition.Line, l.currPosition.Column)
+		return "", fmt.Errorf("Unable to write octal integer literal (%q) at %s", token.OCTAL_PREFIX, l.currPosition)
 	}
 	if err := l.readChar(); err != nil {
 		return "", err
@@ -330,7 +468,7 @@ func (l *Lexer) readOctalInteger() (string, error) {
 
 	for isOctalDigit(l.ch) {
 		if _, err := builder.WriteRune(l.ch); err != nil {
-			return "", fmt.Errorf("Unable to write octal integer literal (%q) at line %d, column %d", l.ch, l.currPosition.Line, l.currPosition.Column)
+			return "", fmt.Errorf("Unable to write octal integer literal (%q) at %s", l.ch, l.currPosition)
 		}
 		if err := l.readChar(); err != nil {
 			return "", err
@@ -346,13 +484,13 @@ func (l *Lexer) readHexadecimalInteger() (string, error) {
 
 	// write the 0
 	if _, err := builder.WriteRune(l.ch); err != nil {
-		return "", fmt.Errorf("Unable to write hexadecimal integer literal (%q) at line %d, column %d", l.ch, l.currPosition.Line, l.currPosition.Column)
+		return "", fmt.Errorf("Unable to write hexadecimal integer literal (%q) at %s", l.ch, l.currPosition)
 	}
 	if err := l.readChar(); err != nil {
 		return "", err
 	}
 	if _, err := builder.WriteRune(token.HEXADECIMAL_PREFIX); err != nil {
-		return "", fmt.Errorf("Unable to write hexadecimal integer literal (%q) at line %d, column %d", token.HEXADECIMAL_PREFIX, l.currPosition.Line, l.currPosition.Column)
+		return "", fmt.Errorf("Unable to write hexadecimal integer literal (%q) at %s", token.HEXADECIMAL_PREFIX, l.currPosition)
 	}
 	if err := l.readChar(); err != nil {
 		return "", err
@@ -360,7 +498,7 @@ func (l *Lexer) readHexadecimalInteger() (string, error) {
 
 	for isHexadecimalDigit(l.ch) {
 		if _, err := builder.WriteRune(l.ch); err != nil {
-			return "", fmt.Errorf("Unable to write hexadecimal integer literal (%q) at line %d, column %d", l.ch, l.currPosition.Line, l.currPosition.Column)
+			return "", fmt.Errorf("Unable to write hexadecimal integer literal (%q) at %s", l.ch, l.currPosition)
 		}
 		if err := l.readChar(); err != nil {
 			return "", err
@@ -370,9 +508,51 @@ func (l *Lexer) readHexadecimalInteger() (string, error) {
 	return builder.String(), nil
 }
 
+// readString reads a string literal. The surrounding quotes are not part of
+// the result. The lexer is left on the closing quote.
+func (l *Lexer) readString() (string, error) {
+	start := l.currPosition.Copy()
+
+	var builder strings.Builder
+	for {
+		if err := l.readChar(); err != nil {
+			return "", err
+		}
+
+		if l.ch == '"' {
+			break
+		} else if l.ch == 0 {
+			return "", fmt.Errorf("Unterminated string literal starting at %s", start)
+		}
+
+		builder.WriteRune(l.ch)
+	}
+
+	return builder.String(), nil
+}
+
+// readWhitespace reads a run of whitespace characters into a WHITESPACE token.
+func (l *Lexer) readWhitespace() (token.Token, error) {
+	tok := token.Token{Type: token.WHITESPACE}
+	tok.Span.Start = l.currPosition.Copy()
+
+	var builder strings.Builder
+	for isWhitespace(l.ch) {
+		builder.WriteRune(l.ch)
+		tok.Span.End = l.currPosition.Copy()
+		if err := l.readChar(); err != nil {
+			return tok, err
+		}
+	}
+
+	tok.Literal = builder.String()
+
+	return tok, nil
+}
+
 // consumeWhitespace eats all whitespace characters between tokens.
 func (l *Lexer) consumeWhitespace() error {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\r' || l.ch == '\n' {
+	for isWhitespace(l.ch) {
 		if err := l.readChar(); err != nil {
 			return err
 		}
@@ -1,19 +1,75 @@
 package lexer
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 
 	"git.sr.ht/~tristan957/monkey/token"
 )
 
+// expectedToken is a token a test expects the lexer to produce.
+type expectedToken struct {
+	expectedType    token.Type
+	expectedLiteral string
+	expectedSpan    token.Span
+}
+
+// expectToken checks that tok, the i-th token produced, matches tt.
+func expectToken(t *testing.T, i int, tok token.Token, tt expectedToken) {
+	t.Helper()
+
+	if tok.Type != tt.expectedType {
+		t.Fatalf("tests[%d] - token type wrong, expected=%q, actual=%q", i, tt.expectedType, tok.Type)
+	}
+
+	if tok.Literal != tt.expectedLiteral {
+		t.Fatalf("tests[%d] - literal wrong, expected=%q, actual=%q", i, tt.expectedLiteral, tok.Literal)
+	}
+
+	if !tok.Span.Equals(&tt.expectedSpan) {
+		t.Fatalf("tests[%d] - wrong span, expected=%s, actual=%s", i, tt.expectedSpan, tok.Span)
+	}
+}
+
+// expectTokens initializes l and checks that it produces the tokens in tests,
+// in order.
+func expectTokens(t *testing.T, l *Lexer, tests []expectedToken) {
+	t.Helper()
+
+	if err := l.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tt := range tests {
+		tok, err := l.NextToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectToken(t, i, tok, tt)
+	}
+}
+
+// expectTokenList checks that actual holds exactly the tokens in tests.
+func expectTokenList(t *testing.T, actual []token.Token, tests []expectedToken) {
+	t.Helper()
+
+	if len(actual) != len(tests) {
+		t.Fatalf("token count wrong, expected=%d, actual=%d", len(tests), len(actual))
+	}
+
+	for i, tt := range tests {
+		expectToken(t, i, actual[i], tt)
+	}
+}
+
 func TestNextToken_OneLineString(t *testing.T) {
 	input := "=+-*/(){},;<>!"
 
-	tests := []struct {
-		expectedType    token.Type
-		expectedLiteral string
-		expectedSpan    token.Span
-	}{
+	tests := []expectedToken{
 		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 1, Column: 1}, End: &token.Position{Line: 1, Column: 1}}},
 		{token.PLUS, "+", token.Span{Start: &token.Position{Line: 1, Column: 2}, End: &token.Position{Line: 1, Column: 2}}},
 		{token.MINUS, "-", token.Span{Start: &token.Position{Line: 1, Column: 3}, End: &token.Position{Line: 1, Column: 3}}},
@@ -31,28 +87,64 @@ func TestNextToken_OneLineString(t *testing.T) {
 		{token.EOF, "", token.Span{Start: &token.Position{Line: 1, Column: 15}, End: &token.Position{Line: 1, Column: 15}}},
 	}
 
+	expectTokens(t, NewFromString(input), tests)
+}
+
+func TestNextToken_SingleCharacterTypes(t *testing.T) {
+	input := "=+-*/(){}[],;:<>!"
+
 	l := NewFromString(input)
 	if err := l.Initialize(); err != nil {
 		t.Fatal(err)
 	}
 
-	for i, tt := range tests {
+	for i := 0; i < len(input); i++ {
 		tok, err := l.NextToken()
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		if tok.Type != tt.expectedType {
-			t.Fatalf("tests[%d] - token type wrong, expected=%q, actual=%q", i, tt.expectedType, tok.Type)
+		if string(tok.Type) != tok.Literal {
+			t.Fatalf("tests[%d] - token type wrong, expected=%q, actual=%q", i, tok.Literal, tok.Type)
 		}
+	}
+}
 
-		if tok.Literal != tt.expectedLiteral {
-			t.Fatalf("tests[%d] - literal wrong, expected=%q, actual=%q", i, tt.expectedLiteral, tok.Literal)
-		}
+// failingReader returns its input and then fails with err.
+type failingReader struct {
+	input string
+	err   error
+}
 
-		if !tok.Span.Equals(&tt.expectedSpan) {
-			t.Fatalf("tests[%d] - wrong span, expected=%s, actual=%s", i, tt.expectedSpan, tok.Span)
-		}
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.input == "" {
+		return 0, r.err
+	}
+
+	n := copy(p, r.input)
+	r.input = r.input[n:]
+
+	return n, nil
+}
+
+func TestNextToken_WhitespaceReadError(t *testing.T) {
+	readErr := errors.New("read failed")
+	l := NewFromReader(&failingReader{input: "let  ", err: readErr})
+	if err := l.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := l.NextToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tok.Type != token.LET {
+		t.Fatalf("token type wrong, expected=%q, actual=%q", token.LET, tok.Type)
+	}
+
+	if _, err := l.NextToken(); !errors.Is(err, readErr) {
+		t.Fatalf("wrong error, expected=%v, actual=%v", readErr, err)
 	}
 }
 
@@ -77,11 +169,7 @@ let o = 0o123_456;
 let h = 0xabF_127;
 `
 
-	tests := []struct {
-		expectedType    token.Type
-		expectedLiteral string
-		expectedSpan    token.Span
-	}{
+	tests := []expectedToken{
 		{token.LET, "let", token.Span{Start: &token.Position{Line: 1, Column: 1}, End: &token.Position{Line: 1, Column: 3}}},
 		{token.IDENTIFIER, "five", token.Span{Start: &token.Position{Line: 1, Column: 5}, End: &token.Position{Line: 1, Column: 8}}},
 		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 1, Column: 10}, End: &token.Position{Line: 1, Column: 10}}},
@@ -153,27 +241,507 @@ let h = 0xabF_127;
 		{token.EOF, "", token.Span{Start: &token.Position{Line: 19, Column: 1}, End: &token.Position{Line: 19, Column: 1}}},
 	}
 
-	l := NewFromString(input)
+	expectTokens(t, NewFromString(input), tests)
+}
+
+func TestNextToken_Macro(t *testing.T) {
+	input := `let unless = macro(cond, then) {
+	quote(if (!(unquote(cond))) { unquote(then) });
+};`
+
+	tests := []expectedToken{
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 1, Column: 1}, End: &token.Position{Line: 1, Column: 3}}},
+		{token.IDENTIFIER, "unless", token.Span{Start: &token.Position{Line: 1, Column: 5}, End: &token.Position{Line: 1, Column: 10}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 1, Column: 12}, End: &token.Position{Line: 1, Column: 12}}},
+		{token.MACRO, "macro", token.Span{Start: &token.Position{Line: 1, Column: 14}, End: &token.Position{Line: 1, Column: 18}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 1, Column: 19}, End: &token.Position{Line: 1, Column: 19}}},
+		{token.IDENTIFIER, "cond", token.Span{Start: &token.Position{Line: 1, Column: 20}, End: &token.Position{Line: 1, Column: 23}}},
+		{token.COMMA, ",", token.Span{Start: &token.Position{Line: 1, Column: 24}, End: &token.Position{Line: 1, Column: 24}}},
+		{token.IDENTIFIER, "then", token.Span{Start: &token.Position{Line: 1, Column: 26}, End: &token.Position{Line: 1, Column: 29}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 1, Column: 30}, End: &token.Position{Line: 1, Column: 30}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 1, Column: 32}, End: &token.Position{Line: 1, Column: 32}}},
+		{token.IDENTIFIER, "quote", token.Span{Start: &token.Position{Line: 2, Column: 2}, End: &token.Position{Line: 2, Column: 6}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 2, Column: 7}, End: &token.Position{Line: 2, Column: 7}}},
+		{token.IF, "if", token.Span{Start: &token.Position{Line: 2, Column: 8}, End: &token.Position{Line: 2, Column: 9}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 2, Column: 11}, End: &token.Position{Line: 2, Column: 11}}},
+		{token.BANG, "!", token.Span{Start: &token.Position{Line: 2, Column: 12}, End: &token.Position{Line: 2, Column: 12}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 2, Column: 13}, End: &token.Position{Line: 2, Column: 13}}},
+		{token.IDENTIFIER, "unquote", token.Span{Start: &token.Position{Line: 2, Column: 14}, End: &token.Position{Line: 2, Column: 20}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 2, Column: 21}, End: &token.Position{Line: 2, Column: 21}}},
+		{token.IDENTIFIER, "cond", token.Span{Start: &token.Position{Line: 2, Column: 22}, End: &token.Position{Line: 2, Column: 25}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 2, Column: 26}, End: &token.Position{Line: 2, Column: 26}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 2, Column: 27}, End: &token.Position{Line: 2, Column: 27}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 2, Column: 28}, End: &token.Position{Line: 2, Column: 28}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 2, Column: 30}, End: &token.Position{Line: 2, Column: 30}}},
+		{token.IDENTIFIER, "unquote", token.Span{Start: &token.Position{Line: 2, Column: 32}, End: &token.Position{Line: 2, Column: 38}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 2, Column: 39}, End: &token.Position{Line: 2, Column: 39}}},
+		{token.IDENTIFIER, "then", token.Span{Start: &token.Position{Line: 2, Column: 40}, End: &token.Position{Line: 2, Column: 43}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 2, Column: 44}, End: &token.Position{Line: 2, Column: 44}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 2, Column: 46}, End: &token.Position{Line: 2, Column: 46}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 2, Column: 47}, End: &token.Position{Line: 2, Column: 47}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 2, Column: 48}, End: &token.Position{Line: 2, Column: 48}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 3, Column: 1}, End: &token.Position{Line: 3, Column: 1}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 3, Column: 2}, End: &token.Position{Line: 3, Column: 2}}},
+		{token.EOF, "", token.Span{Start: &token.Position{Line: 3, Column: 3}, End: &token.Position{Line: 3, Column: 3}}},
+	}
+
+	expectTokens(t, NewFromString(input), tests)
+}
+
+func TestNextToken_Loops(t *testing.T) {
+	input := `while (x < 10) {
+	if (x) { continue; } else { break; }
+};
+
+for item in items {
+	item;
+};`
+
+	tests := []expectedToken{
+		{token.WHILE, "while", token.Span{Start: &token.Position{Line: 1, Column: 1}, End: &token.Position{Line: 1, Column: 5}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 1, Column: 7}, End: &token.Position{Line: 1, Column: 7}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 1, Column: 8}, End: &token.Position{Line: 1, Column: 8}}},
+		{token.LESS_THAN, "<", token.Span{Start: &token.Position{Line: 1, Column: 10}, End: &token.Position{Line: 1, Column: 10}}},
+		{token.INTEGER, "10", token.Span{Start: &token.Position{Line: 1, Column: 12}, End: &token.Position{Line: 1, Column: 13}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 1, Column: 14}, End: &token.Position{Line: 1, Column: 14}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 1, Column: 16}, End: &token.Position{Line: 1, Column: 16}}},
+		{token.IF, "if", token.Span{Start: &token.Position{Line: 2, Column: 2}, End: &token.Position{Line: 2, Column: 3}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 2, Column: 5}, End: &token.Position{Line: 2, Column: 5}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 2, Column: 6}, End: &token.Position{Line: 2, Column: 6}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 2, Column: 7}, End: &token.Position{Line: 2, Column: 7}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 2, Column: 9}, End: &token.Position{Line: 2, Column: 9}}},
+		{token.CONTINUE, "continue", token.Span{Start: &token.Position{Line: 2, Column: 11}, End: &token.Position{Line: 2, Column: 18}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 2, Column: 19}, End: &token.Position{Line: 2, Column: 19}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 2, Column: 21}, End: &token.Position{Line: 2, Column: 21}}},
+		{token.ELSE, "else", token.Span{Start: &token.Position{Line: 2, Column: 23}, End: &token.Position{Line: 2, Column: 26}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 2, Column: 28}, End: &token.Position{Line: 2, Column: 28}}},
+		{token.BREAK, "break", token.Span{Start: &token.Position{Line: 2, Column: 30}, End: &token.Position{Line: 2, Column: 34}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 2, Column: 35}, End: &token.Position{Line: 2, Column: 35}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 2, Column: 37}, End: &token.Position{Line: 2, Column: 37}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 3, Column: 1}, End: &token.Position{Line: 3, Column: 1}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 3, Column: 2}, End: &token.Position{Line: 3, Column: 2}}},
+		{token.FOR, "for", token.Span{Start: &token.Position{Line: 5, Column: 1}, End: &token.Position{Line: 5, Column: 3}}},
+		{token.IDENTIFIER, "item", token.Span{Start: &token.Position{Line: 5, Column: 5}, End: &token.Position{Line: 5, Column: 8}}},
+		{token.IN, "in", token.Span{Start: &token.Position{Line: 5, Column: 10}, End: &token.Position{Line: 5, Column: 11}}},
+		{token.IDENTIFIER, "items", token.Span{Start: &token.Position{Line: 5, Column: 13}, End: &token.Position{Line: 5, Column: 17}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 5, Column: 19}, End: &token.Position{Line: 5, Column: 19}}},
+		{token.IDENTIFIER, "item", token.Span{Start: &token.Position{Line: 6, Column: 2}, End: &token.Position{Line: 6, Column: 5}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 6, Column: 6}, End: &token.Position{Line: 6, Column: 6}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 7, Column: 1}, End: &token.Position{Line: 7, Column: 1}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 7, Column: 2}, End: &token.Position{Line: 7, Column: 2}}},
+		{token.EOF, "", token.Span{Start: &token.Position{Line: 7, Column: 3}, End: &token.Position{Line: 7, Column: 3}}},
+	}
+
+	expectTokens(t, NewFromString(input), tests)
+}
+
+func TestNextToken_Assignment(t *testing.T) {
+	input := `let x = 0;
+x = x + 1;
+x += 2;
+x -= 3;
+x *= 4;
+x /= 5;
+x %= 6;
+arr[0] = 1;
+x+=-1;`
+
+	tests := []expectedToken{
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 1, Column: 1}, End: &token.Position{Line: 1, Column: 3}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 1, Column: 5}, End: &token.Position{Line: 1, Column: 5}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 1, Column: 7}, End: &token.Position{Line: 1, Column: 7}}},
+		{token.INTEGER, "0", token.Span{Start: &token.Position{Line: 1, Column: 9}, End: &token.Position{Line: 1, Column: 9}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 1, Column: 10}, End: &token.Position{Line: 1, Column: 10}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 2, Column: 1}, End: &token.Position{Line: 2, Column: 1}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 2, Column: 3}, End: &token.Position{Line: 2, Column: 3}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 2, Column: 5}, End: &token.Position{Line: 2, Column: 5}}},
+		{token.PLUS, "+", token.Span{Start: &token.Position{Line: 2, Column: 7}, End: &token.Position{Line: 2, Column: 7}}},
+		{token.INTEGER, "1", token.Span{Start: &token.Position{Line: 2, Column: 9}, End: &token.Position{Line: 2, Column: 9}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 2, Column: 10}, End: &token.Position{Line: 2, Column: 10}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 3, Column: 1}, End: &token.Position{Line: 3, Column: 1}}},
+		{token.PLUS_ASSIGN, "+=", token.Span{Start: &token.Position{Line: 3, Column: 3}, End: &token.Position{Line: 3, Column: 4}}},
+		{token.INTEGER, "2", token.Span{Start: &token.Position{Line: 3, Column: 6}, End: &token.Position{Line: 3, Column: 6}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 3, Column: 7}, End: &token.Position{Line: 3, Column: 7}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 4, Column: 1}, End: &token.Position{Line: 4, Column: 1}}},
+		{token.MINUS_ASSIGN, "-=", token.Span{Start: &token.Position{Line: 4, Column: 3}, End: &token.Position{Line: 4, Column: 4}}},
+		{token.INTEGER, "3", token.Span{Start: &token.Position{Line: 4, Column: 6}, End: &token.Position{Line: 4, Column: 6}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 4, Column: 7}, End: &token.Position{Line: 4, Column: 7}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 5, Column: 1}, End: &token.Position{Line: 5, Column: 1}}},
+		{token.ASTERISK_ASSIGN, "*=", token.Span{Start: &token.Position{Line: 5, Column: 3}, End: &token.Position{Line: 5, Column: 4}}},
+		{token.INTEGER, "4", token.Span{Start: &token.Position{Line: 5, Column: 6}, End: &token.Position{Line: 5, Column: 6}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 5, Column: 7}, End: &token.Position{Line: 5, Column: 7}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 6, Column: 1}, End: &token.Position{Line: 6, Column: 1}}},
+		{token.FORWARD_SLASH_ASSIGN, "/=", token.Span{Start: &token.Position{Line: 6, Column: 3}, End: &token.Position{Line: 6, Column: 4}}},
+		{token.INTEGER, "5", token.Span{Start: &token.Position{Line: 6, Column: 6}, End: &token.Position{Line: 6, Column: 6}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 6, Column: 7}, End: &token.Position{Line: 6, Column: 7}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 7, Column: 1}, End: &token.Position{Line: 7, Column: 1}}},
+		{token.PERCENT_ASSIGN, "%=", token.Span{Start: &token.Position{Line: 7, Column: 3}, End: &token.Position{Line: 7, Column: 4}}},
+		{token.INTEGER, "6", token.Span{Start: &token.Position{Line: 7, Column: 6}, End: &token.Position{Line: 7, Column: 6}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 7, Column: 7}, End: &token.Position{Line: 7, Column: 7}}},
+		{token.IDENTIFIER, "arr", token.Span{Start: &token.Position{Line: 8, Column: 1}, End: &token.Position{Line: 8, Column: 3}}},
+		{token.LEFT_BRACKET, "[", token.Span{Start: &token.Position{Line: 8, Column: 4}, End: &token.Position{Line: 8, Column: 4}}},
+		{token.INTEGER, "0", token.Span{Start: &token.Position{Line: 8, Column: 5}, End: &token.Position{Line: 8, Column: 5}}},
+		{token.RIGHT_BRACKET, "]", token.Span{Start: &token.Position{Line: 8, Column: 6}, End: &token.Position{Line: 8, Column: 6}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 8, Column: 8}, End: &token.Position{Line: 8, Column: 8}}},
+		{token.INTEGER, "1", token.Span{Start: &token.Position{Line: 8, Column: 10}, End: &token.Position{Line: 8, Column: 10}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 8, Column: 11}, End: &token.Position{Line: 8, Column: 11}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 9, Column: 1}, End: &token.Position{Line: 9, Column: 1}}},
+		{token.PLUS_ASSIGN, "+=", token.Span{Start: &token.Position{Line: 9, Column: 2}, End: &token.Position{Line: 9, Column: 3}}},
+		{token.MINUS, "-", token.Span{Start: &token.Position{Line: 9, Column: 4}, End: &token.Position{Line: 9, Column: 4}}},
+		{token.INTEGER, "1", token.Span{Start: &token.Position{Line: 9, Column: 5}, End: &token.Position{Line: 9, Column: 5}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 9, Column: 6}, End: &token.Position{Line: 9, Column: 6}}},
+		{token.EOF, "", token.Span{Start: &token.Position{Line: 9, Column: 7}, End: &token.Position{Line: 9, Column: 7}}},
+	}
+
+	expectTokens(t, NewFromString(input), tests)
+}
+
+func TestNextToken_Operators(t *testing.T) {
+	input := `let a = x % 2 ** 3;
+let b = ~0xff & 0b1010 | 0o17 ^ 1;
+let c = 1 << 4 >> 2;
+let d = a < b && c > 0 || !d;`
+
+	tests := []expectedToken{
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 1, Column: 1}, End: &token.Position{Line: 1, Column: 3}}},
+		{token.IDENTIFIER, "a", token.Span{Start: &token.Position{Line: 1, Column: 5}, End: &token.Position{Line: 1, Column: 5}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 1, Column: 7}, End: &token.Position{Line: 1, Column: 7}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 1, Column: 9}, End: &token.Position{Line: 1, Column: 9}}},
+		{token.PERCENT, "%", token.Span{Start: &token.Position{Line: 1, Column: 11}, End: &token.Position{Line: 1, Column: 11}}},
+		{token.INTEGER, "2", token.Span{Start: &token.Position{Line: 1, Column: 13}, End: &token.Position{Line: 1, Column: 13}}},
+		{token.DOUBLE_ASTERISK, "**", token.Span{Start: &token.Position{Line: 1, Column: 15}, End: &token.Position{Line: 1, Column: 16}}},
+		{token.INTEGER, "3", token.Span{Start: &token.Position{Line: 1, Column: 18}, End: &token.Position{Line: 1, Column: 18}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 1, Column: 19}, End: &token.Position{Line: 1, Column: 19}}},
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 2, Column: 1}, End: &token.Position{Line: 2, Column: 3}}},
+		{token.IDENTIFIER, "b", token.Span{Start: &token.Position{Line: 2, Column: 5}, End: &token.Position{Line: 2, Column: 5}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 2, Column: 7}, End: &token.Position{Line: 2, Column: 7}}},
+		{token.TILDE, "~", token.Span{Start: &token.Position{Line: 2, Column: 9}, End: &token.Position{Line: 2, Column: 9}}},
+		{token.INTEGER, "0xff", token.Span{Start: &token.Position{Line: 2, Column: 10}, End: &token.Position{Line: 2, Column: 13}}},
+		{token.AMPERSAND, "&", token.Span{Start: &token.Position{Line: 2, Column: 15}, End: &token.Position{Line: 2, Column: 15}}},
+		{token.INTEGER, "0b1010", token.Span{Start: &token.Position{Line: 2, Column: 17}, End: &token.Position{Line: 2, Column: 22}}},
+		{token.PIPE, "|", token.Span{Start: &token.Position{Line: 2, Column: 24}, End: &token.Position{Line: 2, Column: 24}}},
+		{token.INTEGER, "0o17", token.Span{Start: &token.Position{Line: 2, Column: 26}, End: &token.Position{Line: 2, Column: 29}}},
+		{token.CARET, "^", token.Span{Start: &token.Position{Line: 2, Column: 31}, End: &token.Position{Line: 2, Column: 31}}},
+		{token.INTEGER, "1", token.Span{Start: &token.Position{Line: 2, Column: 33}, End: &token.Position{Line: 2, Column: 33}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 2, Column: 34}, End: &token.Position{Line: 2, Column: 34}}},
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 3, Column: 1}, End: &token.Position{Line: 3, Column: 3}}},
+		{token.IDENTIFIER, "c", token.Span{Start: &token.Position{Line: 3, Column: 5}, End: &token.Position{Line: 3, Column: 5}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 3, Column: 7}, End: &token.Position{Line: 3, Column: 7}}},
+		{token.INTEGER, "1", token.Span{Start: &token.Position{Line: 3, Column: 9}, End: &token.Position{Line: 3, Column: 9}}},
+		{token.LEFT_SHIFT, "<<", token.Span{Start: &token.Position{Line: 3, Column: 11}, End: &token.Position{Line: 3, Column: 12}}},
+		{token.INTEGER, "4", token.Span{Start: &token.Position{Line: 3, Column: 14}, End: &token.Position{Line: 3, Column: 14}}},
+		{token.RIGHT_SHIFT, ">>", token.Span{Start: &token.Position{Line: 3, Column: 16}, End: &token.Position{Line: 3, Column: 17}}},
+		{token.INTEGER, "2", token.Span{Start: &token.Position{Line: 3, Column: 19}, End: &token.Position{Line: 3, Column: 19}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 3, Column: 20}, End: &token.Position{Line: 3, Column: 20}}},
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 4, Column: 1}, End: &token.Position{Line: 4, Column: 3}}},
+		{token.IDENTIFIER, "d", token.Span{Start: &token.Position{Line: 4, Column: 5}, End: &token.Position{Line: 4, Column: 5}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 4, Column: 7}, End: &token.Position{Line: 4, Column: 7}}},
+		{token.IDENTIFIER, "a", token.Span{Start: &token.Position{Line: 4, Column: 9}, End: &token.Position{Line: 4, Column: 9}}},
+		{token.LESS_THAN, "<", token.Span{Start: &token.Position{Line: 4, Column: 11}, End: &token.Position{Line: 4, Column: 11}}},
+		{token.IDENTIFIER, "b", token.Span{Start: &token.Position{Line: 4, Column: 13}, End: &token.Position{Line: 4, Column: 13}}},
+		{token.AND, "&&", token.Span{Start: &token.Position{Line: 4, Column: 15}, End: &token.Position{Line: 4, Column: 16}}},
+		{token.IDENTIFIER, "c", token.Span{Start: &token.Position{Line: 4, Column: 18}, End: &token.Position{Line: 4, Column: 18}}},
+		{token.GREATER_THAN, ">", token.Span{Start: &token.Position{Line: 4, Column: 20}, End: &token.Position{Line: 4, Column: 20}}},
+		{token.INTEGER, "0", token.Span{Start: &token.Position{Line: 4, Column: 22}, End: &token.Position{Line: 4, Column: 22}}},
+		{token.OR, "||", token.Span{Start: &token.Position{Line: 4, Column: 24}, End: &token.Position{Line: 4, Column: 25}}},
+		{token.BANG, "!", token.Span{Start: &token.Position{Line: 4, Column: 27}, End: &token.Position{Line: 4, Column: 27}}},
+		{token.IDENTIFIER, "d", token.Span{Start: &token.Position{Line: 4, Column: 28}, End: &token.Position{Line: 4, Column: 28}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 4, Column: 29}, End: &token.Position{Line: 4, Column: 29}}},
+		{token.EOF, "", token.Span{Start: &token.Position{Line: 4, Column: 30}, End: &token.Position{Line: 4, Column: 30}}},
+	}
+
+	expectTokens(t, NewFromString(input), tests)
+}
+
+func TestNextToken_Modules(t *testing.T) {
+	input := `import "lib/math.mk";
+export let pi = 3;
+let s = "hello, world";
+let e = "";`
+
+	tests := []expectedToken{
+		{token.IMPORT, "import", token.Span{Start: &token.Position{Line: 1, Column: 1}, End: &token.Position{Line: 1, Column: 6}}},
+		{token.STRING, "lib/math.mk", token.Span{Start: &token.Position{Line: 1, Column: 8}, End: &token.Position{Line: 1, Column: 20}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 1, Column: 21}, End: &token.Position{Line: 1, Column: 21}}},
+		{token.EXPORT, "export", token.Span{Start: &token.Position{Line: 2, Column: 1}, End: &token.Position{Line: 2, Column: 6}}},
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 2, Column: 8}, End: &token.Position{Line: 2, Column: 10}}},
+		{token.IDENTIFIER, "pi", token.Span{Start: &token.Position{Line: 2, Column: 12}, End: &token.Position{Line: 2, Column: 13}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 2, Column: 15}, End: &token.Position{Line: 2, Column: 15}}},
+		{token.INTEGER, "3", token.Span{Start: &token.Position{Line: 2, Column: 17}, End: &token.Position{Line: 2, Column: 17}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 2, Column: 18}, End: &token.Position{Line: 2, Column: 18}}},
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 3, Column: 1}, End: &token.Position{Line: 3, Column: 3}}},
+		{token.IDENTIFIER, "s", token.Span{Start: &token.Position{Line: 3, Column: 5}, End: &token.Position{Line: 3, Column: 5}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 3, Column: 7}, End: &token.Position{Line: 3, Column: 7}}},
+		{token.STRING, "hello, world", token.Span{Start: &token.Position{Line: 3, Column: 9}, End: &token.Position{Line: 3, Column: 22}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 3, Column: 23}, End: &token.Position{Line: 3, Column: 23}}},
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 4, Column: 1}, End: &token.Position{Line: 4, Column: 3}}},
+		{token.IDENTIFIER, "e", token.Span{Start: &token.Position{Line: 4, Column: 5}, End: &token.Position{Line: 4, Column: 5}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 4, Column: 7}, End: &token.Position{Line: 4, Column: 7}}},
+		{token.STRING, "", token.Span{Start: &token.Position{Line: 4, Column: 9}, End: &token.Position{Line: 4, Column: 10}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 4, Column: 11}, End: &token.Position{Line: 4, Column: 11}}},
+		{token.EOF, "", token.Span{Start: &token.Position{Line: 4, Column: 12}, End: &token.Position{Line: 4, Column: 12}}},
+	}
+
+	expectTokens(t, NewFromString(input), tests)
+}
+
+func TestNextToken_UnterminatedString(t *testing.T) {
+	l := NewFromString(`let s = "abc`)
 	if err := l.Initialize(); err != nil {
 		t.Fatal(err)
 	}
 
-	for i, tt := range tests {
-		tok, err := l.NextToken()
-		if err != nil {
+	for i := 0; i < 3; i++ {
+		if _, err := l.NextToken(); err != nil {
 			t.Fatal(err)
 		}
+	}
+
+	if _, err := l.NextToken(); err == nil {
+		t.Fatal("expected an error for an unterminated string literal")
+	}
+}
+
+func TestNewFromReaderWithFile(t *testing.T) {
+	l := NewFromReaderWithFile("buffer.mk", strings.NewReader("\n  let"))
+	if err := l.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := l.NextToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSpan := token.Span{Start: &token.Position{File: "buffer.mk", Line: 2, Column: 3}, End: &token.Position{File: "buffer.mk", Line: 2, Column: 5}}
+	if !tok.Span.Equals(&expectedSpan) {
+		t.Fatalf("wrong span, expected=%s, actual=%s", expectedSpan, tok.Span)
+	}
+}
+
+func TestNextToken_File(t *testing.T) {
+	file, err := ioutil.TempFile("", "*.mk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if _, err := file.WriteString("let x = 1;"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	name := file.Name()
+	tests := []expectedToken{
+		{token.LET, "let", token.Span{Start: &token.Position{File: name, Line: 1, Column: 1}, End: &token.Position{File: name, Line: 1, Column: 3}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{File: name, Line: 1, Column: 5}, End: &token.Position{File: name, Line: 1, Column: 5}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{File: name, Line: 1, Column: 7}, End: &token.Position{File: name, Line: 1, Column: 7}}},
+		{token.INTEGER, "1", token.Span{Start: &token.Position{File: name, Line: 1, Column: 9}, End: &token.Position{File: name, Line: 1, Column: 9}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{File: name, Line: 1, Column: 10}, End: &token.Position{File: name, Line: 1, Column: 10}}},
+		{token.EOF, "", token.Span{Start: &token.Position{File: name, Line: 1, Column: 11}, End: &token.Position{File: name, Line: 1, Column: 11}}},
+	}
 
-		if tok.Type != tt.expectedType {
-			t.Fatalf("tests[%d] - token type wrong, expected=%q, actual=%q", i, tt.expectedType, tok.Type)
+	expectTokens(t, NewFromFile(file), tests)
+}
+
+func TestNextToken_Exceptions(t *testing.T) {
+	input := `try {
+	throw "boom";
+} catch (e) {
+	e;
+} finally {
+	cleanup();
+}`
+
+	tests := []expectedToken{
+		{token.TRY, "try", token.Span{Start: &token.Position{Line: 1, Column: 1}, End: &token.Position{Line: 1, Column: 3}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 1, Column: 5}, End: &token.Position{Line: 1, Column: 5}}},
+		{token.THROW, "throw", token.Span{Start: &token.Position{Line: 2, Column: 2}, End: &token.Position{Line: 2, Column: 6}}},
+		{token.STRING, "boom", token.Span{Start: &token.Position{Line: 2, Column: 8}, End: &token.Position{Line: 2, Column: 13}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 2, Column: 14}, End: &token.Position{Line: 2, Column: 14}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 3, Column: 1}, End: &token.Position{Line: 3, Column: 1}}},
+		{token.CATCH, "catch", token.Span{Start: &token.Position{Line: 3, Column: 3}, End: &token.Position{Line: 3, Column: 7}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 3, Column: 9}, End: &token.Position{Line: 3, Column: 9}}},
+		{token.IDENTIFIER, "e", token.Span{Start: &token.Position{Line: 3, Column: 10}, End: &token.Position{Line: 3, Column: 10}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 3, Column: 11}, End: &token.Position{Line: 3, Column: 11}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 3, Column: 13}, End: &token.Position{Line: 3, Column: 13}}},
+		{token.IDENTIFIER, "e", token.Span{Start: &token.Position{Line: 4, Column: 2}, End: &token.Position{Line: 4, Column: 2}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 4, Column: 3}, End: &token.Position{Line: 4, Column: 3}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 5, Column: 1}, End: &token.Position{Line: 5, Column: 1}}},
+		{token.FINALLY, "finally", token.Span{Start: &token.Position{Line: 5, Column: 3}, End: &token.Position{Line: 5, Column: 9}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 5, Column: 11}, End: &token.Position{Line: 5, Column: 11}}},
+		{token.IDENTIFIER, "cleanup", token.Span{Start: &token.Position{Line: 6, Column: 2}, End: &token.Position{Line: 6, Column: 8}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 6, Column: 9}, End: &token.Position{Line: 6, Column: 9}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 6, Column: 10}, End: &token.Position{Line: 6, Column: 10}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 6, Column: 11}, End: &token.Position{Line: 6, Column: 11}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 7, Column: 1}, End: &token.Position{Line: 7, Column: 1}}},
+		{token.EOF, "", token.Span{Start: &token.Position{Line: 7, Column: 2}, End: &token.Position{Line: 7, Column: 2}}},
+	}
+
+	expectTokens(t, NewFromString(input), tests)
+}
+
+func TestNextToken_Match(t *testing.T) {
+	input := `match (x) {
+	0xff { 1 }
+	[head, _tail] if (head) { head }
+	_ { 0 }
+}`
+
+	tests := []expectedToken{
+		{token.MATCH, "match", token.Span{Start: &token.Position{Line: 1, Column: 1}, End: &token.Position{Line: 1, Column: 5}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 1, Column: 7}, End: &token.Position{Line: 1, Column: 7}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 1, Column: 8}, End: &token.Position{Line: 1, Column: 8}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 1, Column: 9}, End: &token.Position{Line: 1, Column: 9}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 1, Column: 11}, End: &token.Position{Line: 1, Column: 11}}},
+		{token.INTEGER, "0xff", token.Span{Start: &token.Position{Line: 2, Column: 2}, End: &token.Position{Line: 2, Column: 5}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 2, Column: 7}, End: &token.Position{Line: 2, Column: 7}}},
+		{token.INTEGER, "1", token.Span{Start: &token.Position{Line: 2, Column: 9}, End: &token.Position{Line: 2, Column: 9}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 2, Column: 11}, End: &token.Position{Line: 2, Column: 11}}},
+		{token.LEFT_BRACKET, "[", token.Span{Start: &token.Position{Line: 3, Column: 2}, End: &token.Position{Line: 3, Column: 2}}},
+		{token.IDENTIFIER, "head", token.Span{Start: &token.Position{Line: 3, Column: 3}, End: &token.Position{Line: 3, Column: 6}}},
+		{token.COMMA, ",", token.Span{Start: &token.Position{Line: 3, Column: 7}, End: &token.Position{Line: 3, Column: 7}}},
+		{token.IDENTIFIER, "_tail", token.Span{Start: &token.Position{Line: 3, Column: 9}, End: &token.Position{Line: 3, Column: 13}}},
+		{token.RIGHT_BRACKET, "]", token.Span{Start: &token.Position{Line: 3, Column: 14}, End: &token.Position{Line: 3, Column: 14}}},
+		{token.IF, "if", token.Span{Start: &token.Position{Line: 3, Column: 16}, End: &token.Position{Line: 3, Column: 17}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 3, Column: 19}, End: &token.Position{Line: 3, Column: 19}}},
+		{token.IDENTIFIER, "head", token.Span{Start: &token.Position{Line: 3, Column: 20}, End: &token.Position{Line: 3, Column: 23}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 3, Column: 24}, End: &token.Position{Line: 3, Column: 24}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 3, Column: 26}, End: &token.Position{Line: 3, Column: 26}}},
+		{token.IDENTIFIER, "head", token.Span{Start: &token.Position{Line: 3, Column: 28}, End: &token.Position{Line: 3, Column: 31}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 3, Column: 33}, End: &token.Position{Line: 3, Column: 33}}},
+		{token.UNDERSCORE, "_", token.Span{Start: &token.Position{Line: 4, Column: 2}, End: &token.Position{Line: 4, Column: 2}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 4, Column: 4}, End: &token.Position{Line: 4, Column: 4}}},
+		{token.INTEGER, "0", token.Span{Start: &token.Position{Line: 4, Column: 6}, End: &token.Position{Line: 4, Column: 6}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 4, Column: 8}, End: &token.Position{Line: 4, Column: 8}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 5, Column: 1}, End: &token.Position{Line: 5, Column: 1}}},
+		{token.EOF, "", token.Span{Start: &token.Position{Line: 5, Column: 2}, End: &token.Position{Line: 5, Column: 2}}},
+	}
+
+	expectTokens(t, NewFromString(input), tests)
+}
+
+func TestNextToken_TypeAnnotations(t *testing.T) {
+	input := `let x: int = 5;
+let add = fn(a: int, b: int) -> int {
+	a + b;
+};
+add(x, -x);`
+
+	tests := []expectedToken{
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 1, Column: 1}, End: &token.Position{Line: 1, Column: 3}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 1, Column: 5}, End: &token.Position{Line: 1, Column: 5}}},
+		{token.COLON, ":", token.Span{Start: &token.Position{Line: 1, Column: 6}, End: &token.Position{Line: 1, Column: 6}}},
+		{token.IDENTIFIER, "int", token.Span{Start: &token.Position{Line: 1, Column: 8}, End: &token.Position{Line: 1, Column: 10}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 1, Column: 12}, End: &token.Position{Line: 1, Column: 12}}},
+		{token.INTEGER, "5", token.Span{Start: &token.Position{Line: 1, Column: 14}, End: &token.Position{Line: 1, Column: 14}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 1, Column: 15}, End: &token.Position{Line: 1, Column: 15}}},
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 2, Column: 1}, End: &token.Position{Line: 2, Column: 3}}},
+		{token.IDENTIFIER, "add", token.Span{Start: &token.Position{Line: 2, Column: 5}, End: &token.Position{Line: 2, Column: 7}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 2, Column: 9}, End: &token.Position{Line: 2, Column: 9}}},
+		{token.FUNCTION, "fn", token.Span{Start: &token.Position{Line: 2, Column: 11}, End: &token.Position{Line: 2, Column: 12}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 2, Column: 13}, End: &token.Position{Line: 2, Column: 13}}},
+		{token.IDENTIFIER, "a", token.Span{Start: &token.Position{Line: 2, Column: 14}, End: &token.Position{Line: 2, Column: 14}}},
+		{token.COLON, ":", token.Span{Start: &token.Position{Line: 2, Column: 15}, End: &token.Position{Line: 2, Column: 15}}},
+		{token.IDENTIFIER, "int", token.Span{Start: &token.Position{Line: 2, Column: 17}, End: &token.Position{Line: 2, Column: 19}}},
+		{token.COMMA, ",", token.Span{Start: &token.Position{Line: 2, Column: 20}, End: &token.Position{Line: 2, Column: 20}}},
+		{token.IDENTIFIER, "b", token.Span{Start: &token.Position{Line: 2, Column: 22}, End: &token.Position{Line: 2, Column: 22}}},
+		{token.COLON, ":", token.Span{Start: &token.Position{Line: 2, Column: 23}, End: &token.Position{Line: 2, Column: 23}}},
+		{token.IDENTIFIER, "int", token.Span{Start: &token.Position{Line: 2, Column: 25}, End: &token.Position{Line: 2, Column: 27}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 2, Column: 28}, End: &token.Position{Line: 2, Column: 28}}},
+		{token.ARROW, "->", token.Span{Start: &token.Position{Line: 2, Column: 30}, End: &token.Position{Line: 2, Column: 31}}},
+		{token.IDENTIFIER, "int", token.Span{Start: &token.Position{Line: 2, Column: 33}, End: &token.Position{Line: 2, Column: 35}}},
+		{token.LEFT_BRACE, "{", token.Span{Start: &token.Position{Line: 2, Column: 37}, End: &token.Position{Line: 2, Column: 37}}},
+		{token.IDENTIFIER, "a", token.Span{Start: &token.Position{Line: 3, Column: 2}, End: &token.Position{Line: 3, Column: 2}}},
+		{token.PLUS, "+", token.Span{Start: &token.Position{Line: 3, Column: 4}, End: &token.Position{Line: 3, Column: 4}}},
+		{token.IDENTIFIER, "b", token.Span{Start: &token.Position{Line: 3, Column: 6}, End: &token.Position{Line: 3, Column: 6}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 3, Column: 7}, End: &token.Position{Line: 3, Column: 7}}},
+		{token.RIGHT_BRACE, "}", token.Span{Start: &token.Position{Line: 4, Column: 1}, End: &token.Position{Line: 4, Column: 1}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 4, Column: 2}, End: &token.Position{Line: 4, Column: 2}}},
+		{token.IDENTIFIER, "add", token.Span{Start: &token.Position{Line: 5, Column: 1}, End: &token.Position{Line: 5, Column: 3}}},
+		{token.LEFT_PARENTHESES, "(", token.Span{Start: &token.Position{Line: 5, Column: 4}, End: &token.Position{Line: 5, Column: 4}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 5, Column: 5}, End: &token.Position{Line: 5, Column: 5}}},
+		{token.COMMA, ",", token.Span{Start: &token.Position{Line: 5, Column: 6}, End: &token.Position{Line: 5, Column: 6}}},
+		{token.MINUS, "-", token.Span{Start: &token.Position{Line: 5, Column: 8}, End: &token.Position{Line: 5, Column: 8}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 5, Column: 9}, End: &token.Position{Line: 5, Column: 9}}},
+		{token.RIGHT_PARENTHESES, ")", token.Span{Start: &token.Position{Line: 5, Column: 10}, End: &token.Position{Line: 5, Column: 10}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 5, Column: 11}, End: &token.Position{Line: 5, Column: 11}}},
+		{token.EOF, "", token.Span{Start: &token.Position{Line: 5, Column: 12}, End: &token.Position{Line: 5, Column: 12}}},
+	}
+
+	expectTokens(t, NewFromString(input), tests)
+}
+
+func TestNextToken_Trivia(t *testing.T) {
+	input := `let x = 1;
+
+	x + 2`
+
+	tests := []expectedToken{
+		{token.LET, "let", token.Span{Start: &token.Position{Line: 1, Column: 1}, End: &token.Position{Line: 1, Column: 3}}},
+		{token.WHITESPACE, " ", token.Span{Start: &token.Position{Line: 1, Column: 4}, End: &token.Position{Line: 1, Column: 4}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 1, Column: 5}, End: &token.Position{Line: 1, Column: 5}}},
+		{token.WHITESPACE, " ", token.Span{Start: &token.Position{Line: 1, Column: 6}, End: &token.Position{Line: 1, Column: 6}}},
+		{token.ASSIGN, "=", token.Span{Start: &token.Position{Line: 1, Column: 7}, End: &token.Position{Line: 1, Column: 7}}},
+		{token.WHITESPACE, " ", token.Span{Start: &token.Position{Line: 1, Column: 8}, End: &token.Position{Line: 1, Column: 8}}},
+		{token.INTEGER, "1", token.Span{Start: &token.Position{Line: 1, Column: 9}, End: &token.Position{Line: 1, Column: 9}}},
+		{token.SEMICOLON, ";", token.Span{Start: &token.Position{Line: 1, Column: 10}, End: &token.Position{Line: 1, Column: 10}}},
+		{token.WHITESPACE, "\n\n\t", token.Span{Start: &token.Position{Line: 1, Column: 11}, End: &token.Position{Line: 3, Column: 1}}},
+		{token.IDENTIFIER, "x", token.Span{Start: &token.Position{Line: 3, Column: 2}, End: &token.Position{Line: 3, Column: 2}}},
+		{token.WHITESPACE, " ", token.Span{Start: &token.Position{Line: 3, Column: 3}, End: &token.Position{Line: 3, Column: 3}}},
+		{token.PLUS, "+", token.Span{Start: &token.Position{Line: 3, Column: 4}, End: &token.Position{Line: 3, Column: 4}}},
+		{token.WHITESPACE, " ", token.Span{Start: &token.Position{Line: 3, Column: 5}, End: &token.Position{Line: 3, Column: 5}}},
+		{token.INTEGER, "2", token.Span{Start: &token.Position{Line: 3, Column: 6}, End: &token.Position{Line: 3, Column: 6}}},
+		{token.EOF, "", token.Span{Start: &token.Position{Line: 3, Column: 7}, End: &token.Position{Line: 3, Column: 7}}},
+	}
+
+	l := NewFromString(input)
+	l.EmitTrivia(true)
+	expectTokens(t, l, tests)
+}
+
+func TestNextToken_ZeroBeforeMultiByteCharacter(t *testing.T) {
+	input := "0é 0"
+
+	tests := []expectedToken{
+		{token.INTEGER, "0", token.Span{Start: &token.Position{Line: 1, Column: 1}, End: &token.Position{Line: 1, Column: 1}}},
+		{token.UNKNOWN, "é", token.Span{Start: &token.Position{Line: 1, Column: 2}, End: &token.Position{Line: 1, Column: 2}}},
+		{token.INTEGER, "0", token.Span{Start: &token.Position{Line: 1, Column: 4}, End: &token.Position{Line: 1, Column: 4}}},
+		{token.EOF, "", token.Span{Start: &token.Position{Line: 1, Column: 5}, End: &token.Position{Line: 1, Column: 5}}},
+	}
+
+	expectTokens(t, NewFromString(input), tests)
+}
+
+func TestNextToken_ErrorsIncludeFile(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"let s = \"abc", `Unterminated string literal starting at f.mk (1, 9)`},
+		{"let i = 0z1;", `Unrecognized integer prefix 'z' at f.mk (1, 10)`},
+	}
+
+	for i, tt := range tests {
+		l := NewFromReaderWithFile("f.mk", strings.NewReader(tt.input))
+		if err := l.Initialize(); err != nil {
+			t.Fatal(err)
 		}
 
-		if tok.Literal != tt.expectedLiteral {
-			t.Fatalf("tests[%d] - literal wrong, expected=%q, actual=%q", i, tt.expectedLiteral, tok.Literal)
+		var err error
+		for err == nil {
+			var tok token.Token
+			tok, err = l.NextToken()
+			if err == nil && tok.Type == token.EOF {
+				t.Fatalf("tests[%d] - expected an error", i)
+			}
 		}
 
-		if !tok.Span.Equals(&tt.expectedSpan) {
-			t.Fatalf("tests[%d] - wrong span, expected=%s, actual=%s", i, tt.expectedSpan, tok.Span)
+		if err.Error() != tt.expectedError {
+			t.Fatalf("tests[%d] - wrong error, expected=%q, actual=%q", i, tt.expectedError, err.Error())
 		}
 	}
 }
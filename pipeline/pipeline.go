@@ -0,0 +1,189 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"git.sr.ht/~tristan957/monkey/lexer"
+	"git.sr.ht/~tristan957/monkey/token"
+)
+
+// Extension is the file extension of Monkey source files.
+const Extension = ".mk"
+
+// Result is the outcome of lexing a single file.
+type Result struct {
+	Path   string
+	Tokens []token.Token
+	// Err is set if the file could not be read or lexed to the end.
+	Err error
+}
+
+// Diagnostic is a problem found in a file. Span is nil when the problem has
+// no precise location, like a file that could not be opened.
+type Diagnostic struct {
+	Path    string
+	Span    *token.Span
+	Message string
+}
+
+// String returns a string representation of a Diagnostic
+func (d Diagnostic) String() string {
+	if d.Span != nil {
+		return fmt.Sprintf("%s: %s", d.Span.Start, d.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", d.Path, d.Message)
+}
+
+// Files expands patterns into the sorted list of Monkey source files they
+// name. A pattern ending in "/..." names every source file in the directory
+// tree below it, "..." alone names every source file below the current
+// directory, and any other pattern names a single file. Paths are cleaned so
+// that a file named by more than one pattern is only listed once.
+func Files(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	for _, pattern := range patterns {
+		if !strings.HasSuffix(pattern, "/...") && pattern != "..." {
+			path := filepath.Clean(pattern)
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				return nil, fmt.Errorf("%s is a directory, use %s/... to name the files in it", pattern, path)
+			}
+
+			if !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
+
+			continue
+		}
+
+		err := filepath.Walk(patternRoot(pattern), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			path = filepath.Clean(path)
+			if info.IsDir() || filepath.Ext(path) != Extension || seen[path] {
+				return nil
+			}
+
+			seen[path] = true
+			files = append(files, path)
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// patternRoot returns the directory a "..." pattern walks.
+func patternRoot(pattern string) string {
+	if pattern == "..." {
+		return "."
+	}
+
+	return filepath.Clean(strings.TrimSuffix(pattern, "..."))
+}
+
+// LexFiles lexes every file in paths using a pool of workers. The results are
+// in the same order as paths, regardless of the order the files finish in.
+func LexFiles(paths []string, workers int) []Result {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]Result, len(paths))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results[j] = LexFile(paths[j])
+			}
+		}()
+	}
+
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// LexFile lexes the file at path. The positions of the tokens carry the path.
+func LexFile(path string) Result {
+	result := Result{Path: path}
+
+	file, err := os.Open(path)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer file.Close()
+
+	l := lexer.NewFromFile(file)
+	if err := l.Initialize(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	for {
+		tok, err := l.NextToken()
+		if err != nil {
+			result.Err = err
+			return result
+		}
+
+		result.Tokens = append(result.Tokens, tok)
+		if tok.Type == token.EOF {
+			return result
+		}
+	}
+}
+
+// Diagnostics collects the problems found in results: unknown tokens, in the
+// order they appear, followed by the error that stopped a file from being
+// lexed. Files are reported in the order of results.
+func Diagnostics(results []Result) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, result := range results {
+		for i := range result.Tokens {
+			tok := &result.Tokens[i]
+			if tok.Type != token.UNKNOWN {
+				continue
+			}
+
+			diagnostics = append(diagnostics, Diagnostic{
+				Path:    result.Path,
+				Span:    &tok.Span,
+				Message: fmt.Sprintf("unknown token %q", tok.Literal),
+			})
+		}
+
+		if result.Err != nil {
+			diagnostics = append(diagnostics, Diagnostic{
+				Path:    result.Path,
+				Message: result.Err.Error(),
+			})
+		}
+	}
+
+	return diagnostics
+}
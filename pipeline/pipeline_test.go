@@ -0,0 +1,203 @@
+package pipeline
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"git.sr.ht/~tristan957/monkey/token"
+)
+
+// writeFiles creates files, keyed by their path relative to a new temporary
+// directory, and returns the directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "monkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, contents := range files {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.mk":         "",
+		"notes.txt":       "",
+		"lib/math.mk":     "",
+		"lib/str/util.mk": "",
+	})
+	defer os.RemoveAll(dir)
+
+	files, err := Files([]string{filepath.Join(dir, "lib") + "/...", dir + "/./main.mk", dir + "/lib/../..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		filepath.Join(dir, "lib/math.mk"),
+		filepath.Join(dir, "lib/str/util.mk"),
+		filepath.Join(dir, "main.mk"),
+	}
+
+	if len(files) != len(expected) {
+		t.Fatalf("wrong files, expected=%q, actual=%q", expected, files)
+	}
+
+	for i := range expected {
+		if files[i] != expected[i] {
+			t.Fatalf("files[%d] wrong, expected=%q, actual=%q", i, expected[i], files[i])
+		}
+	}
+}
+
+func TestFiles_OverlappingPatterns(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"sub/a.mk": "",
+	})
+	defer os.RemoveAll(dir)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	files, err := Files([]string{"./sub/a.mk", "./...", "sub/../sub/..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 || files[0] != filepath.Join("sub", "a.mk") {
+		t.Fatalf("wrong files, expected=%q, actual=%q", []string{filepath.Join("sub", "a.mk")}, files)
+	}
+}
+
+func TestFiles_Directory(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"lib/math.mk": "",
+	})
+	defer os.RemoveAll(dir)
+
+	if _, err := Files([]string{filepath.Join(dir, "lib")}); err == nil {
+		t.Fatal("expected an error for a directory without /...")
+	}
+}
+
+func TestPatternRoot(t *testing.T) {
+	tests := []struct {
+		pattern      string
+		expectedRoot string
+	}{
+		{"...", "."},
+		{"./...", "."},
+		{"/...", "/"},
+		{"lib/...", "lib"},
+		{"./lib/../src/...", "src"},
+	}
+
+	for i, tt := range tests {
+		if root := patternRoot(tt.pattern); root != tt.expectedRoot {
+			t.Fatalf("tests[%d] - wrong root, expected=%q, actual=%q", i, tt.expectedRoot, root)
+		}
+	}
+}
+
+func TestLexFiles(t *testing.T) {
+	sources := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		sources[fmt.Sprintf("%02d.mk", i)] = fmt.Sprintf("let x%s = %d;", string(rune('a'+i%26)), i)
+	}
+	dir := writeFiles(t, sources)
+	defer os.RemoveAll(dir)
+
+	files, err := Files([]string{dir + "/..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := LexFiles(files, 8)
+	if len(results) != len(files) {
+		t.Fatalf("result count wrong, expected=%d, actual=%d", len(files), len(results))
+	}
+
+	for i, result := range results {
+		if result.Path != files[i] {
+			t.Fatalf("results[%d] - path wrong, expected=%q, actual=%q", i, files[i], result.Path)
+		}
+
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+
+		if len(result.Tokens) != 6 {
+			t.Fatalf("results[%d] - token count wrong, expected=6, actual=%d", i, len(result.Tokens))
+		}
+
+		integer := result.Tokens[3]
+		if integer.Literal != fmt.Sprint(i) {
+			t.Fatalf("results[%d] - literal wrong, expected=%q, actual=%q", i, fmt.Sprint(i), integer.Literal)
+		}
+
+		if integer.Span.Start.File != files[i] {
+			t.Fatalf("results[%d] - file wrong, expected=%q, actual=%q", i, files[i], integer.Span.Start.File)
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.mk": "let a = $;\nlet b = ?;",
+		"b.mk": "let ok = 1;",
+		"c.mk": "let s = \"abc",
+	})
+	defer os.RemoveAll(dir)
+
+	files, err := Files([]string{dir + "/..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diagnostics := Diagnostics(LexFiles(files, 3))
+
+	a := filepath.Join(dir, "a.mk")
+	c := filepath.Join(dir, "c.mk")
+	tests := []struct {
+		expectedPath string
+		expectedSpan *token.Span
+	}{
+		{a, &token.Span{Start: &token.Position{File: a, Line: 1, Column: 9}, End: &token.Position{File: a, Line: 1, Column: 9}}},
+		{a, &token.Span{Start: &token.Position{File: a, Line: 2, Column: 9}, End: &token.Position{File: a, Line: 2, Column: 9}}},
+		{c, nil},
+	}
+
+	if len(diagnostics) != len(tests) {
+		t.Fatalf("diagnostic count wrong, expected=%d, actual=%d: %v", len(tests), len(diagnostics), diagnostics)
+	}
+
+	for i, tt := range tests {
+		diagnostic := diagnostics[i]
+
+		if diagnostic.Path != tt.expectedPath {
+			t.Fatalf("tests[%d] - path wrong, expected=%q, actual=%q", i, tt.expectedPath, diagnostic.Path)
+		}
+
+		if (tt.expectedSpan == nil) != (diagnostic.Span == nil) || tt.expectedSpan != nil && !diagnostic.Span.Equals(tt.expectedSpan) {
+			t.Fatalf("tests[%d] - wrong span, expected=%v, actual=%v", i, tt.expectedSpan, diagnostic.Span)
+		}
+	}
+}
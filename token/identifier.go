@@ -1,13 +1,27 @@
 package token
 
 var keywords = map[string]Type{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"import":   IMPORT,
+	"export":   EXPORT,
+	"try":      TRY,
+	"catch":    CATCH,
+	"throw":    THROW,
+	"finally":  FINALLY,
+	"match":    MATCH,
+	"_":        UNDERSCORE,
+	"macro":    MACRO,
 }
 
 // LookupIdentifier turns keyords into a Type
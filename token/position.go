@@ -2,8 +2,10 @@ package token
 
 import "fmt"
 
-// Position represents the point in a file or string where a token is
+// Position represents the point in a file or string where a token is. File
+// is empty when the input did not come from a file.
 type Position struct {
+	File   string
 	Line   int
 	Column int
 }
@@ -14,17 +16,22 @@ func (p *Position) Equals(other *Position) bool {
 		return true
 	}
 
-	return p.Line == other.Line && p.Column == other.Column
+	return p.File == other.File && p.Line == other.Line && p.Column == other.Column
 }
 
 // String returns a string representation of a Position
 func (p Position) String() string {
+	if p.File != "" {
+		return fmt.Sprintf("%s (%d, %d)", p.File, p.Line, p.Column)
+	}
+
 	return fmt.Sprintf("(%d, %d)", p.Line, p.Column)
 }
 
 // Copy returns a new Position with the same values.
 func (p Position) Copy() *Position {
 	return &Position{
+		File:   p.File,
 		Line:   p.Line,
 		Column: p.Column,
 	}
@@ -5,8 +5,20 @@ const (
 	IDENTIFIER = "IDENTIFIER"
 	// INTEGER represents integer constants
 	INTEGER = "INTEGER"
+	// STRING represents string constants
+	STRING = "STRING"
 	// ASSIGN represents the assignment operator
 	ASSIGN = "="
+	// PLUS_ASSIGN represents the addition assignment operator
+	PLUS_ASSIGN = "+="
+	// MINUS_ASSIGN represents the subtraction assignment operator
+	MINUS_ASSIGN = "-="
+	// ASTERISK_ASSIGN represents the multiplication assignment operator
+	ASTERISK_ASSIGN = "*="
+	// FORWARD_SLASH_ASSIGN represents the division assignment operator
+	FORWARD_SLASH_ASSIGN = "/="
+	// PERCENT_ASSIGN represents the modulo assignment operator
+	PERCENT_ASSIGN = "%="
 	// PLUS represents the addition operator
 	PLUS = "+"
 	// MINUS represents the minus operator
@@ -17,6 +29,26 @@ const (
 	ASTERISK = "*"
 	// FORWARD_SLASH represents the forward slash operator
 	FORWARD_SLASH = "/"
+	// PERCENT represents the modulo operator
+	PERCENT = "%"
+	// DOUBLE_ASTERISK represents the exponentiation operator
+	DOUBLE_ASTERISK = "**"
+	// AND represents the logical and operator
+	AND = "&&"
+	// OR represents the logical or operator
+	OR = "||"
+	// AMPERSAND represents the bitwise and operator
+	AMPERSAND = "&"
+	// PIPE represents the bitwise or operator
+	PIPE = "|"
+	// CARET represents the bitwise exclusive or operator
+	CARET = "^"
+	// TILDE represents the bitwise complement operator
+	TILDE = "~"
+	// LEFT_SHIFT represents the left shift operator
+	LEFT_SHIFT = "<<"
+	// RIGHT_SHIFT represents the right shift operator
+	RIGHT_SHIFT = ">>"
 	// LESS_THAN represents the less than operator
 	LESS_THAN = "<"
 	// GREATER_THAN represents the greater than operator
@@ -25,6 +57,10 @@ const (
 	COMMA = ","
 	// SEMICOLON represents the ';' delimiter
 	SEMICOLON = ";"
+	// COLON represents the ':' delimiter
+	COLON = ":"
+	// ARROW represents the '->' delimiter
+	ARROW = "->"
 	// LEFT_PARENTHESES represents an opening parentheses
 	LEFT_PARENTHESES = "("
 	// RIGHT_PARENTHESES represents a closing parentheses
@@ -32,7 +68,11 @@ const (
 	// LEFT_BRACE represents an opening brace
 	LEFT_BRACE = "{"
 	// RIGHT_BRACE represents a closing brace
-	RIGHT_BRACE = "{"
+	RIGHT_BRACE = "}"
+	// LEFT_BRACKET represents an opening bracket
+	LEFT_BRACKET = "["
+	// RIGHT_BRACKET represents a closing bracket
+	RIGHT_BRACKET = "]"
 	// FUNCTION represents the 'fn' keyword
 	FUNCTION = "FUNCTION"
 	// LET represents the 'let' keywork
@@ -47,6 +87,36 @@ const (
 	ELSE = "else"
 	// RETURN represents the 'return' keyword
 	RETURN = "return"
+	// WHILE represents the 'while' keyword
+	WHILE = "while"
+	// FOR represents the 'for' keyword
+	FOR = "for"
+	// IN represents the 'in' keyword
+	IN = "in"
+	// BREAK represents the 'break' keyword
+	BREAK = "break"
+	// CONTINUE represents the 'continue' keyword
+	CONTINUE = "continue"
+	// IMPORT represents the 'import' keyword
+	IMPORT = "import"
+	// EXPORT represents the 'export' keyword
+	EXPORT = "export"
+	// TRY represents the 'try' keyword
+	TRY = "try"
+	// CATCH represents the 'catch' keyword
+	CATCH = "catch"
+	// THROW represents the 'throw' keyword
+	THROW = "throw"
+	// FINALLY represents the 'finally' keyword
+	FINALLY = "finally"
+	// MATCH represents the 'match' keyword
+	MATCH = "match"
+	// UNDERSCORE represents the '_' wildcard
+	UNDERSCORE = "_"
+	// MACRO represents the 'macro' keyword
+	MACRO = "macro"
+	// WHITESPACE represents a run of whitespace between tokens
+	WHITESPACE = "WHITESPACE"
 	// UNKNOWN represents an unknown token
 	UNKNOWN = "UNKNOWN"
 	// EOF represents the end of file